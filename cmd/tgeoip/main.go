@@ -0,0 +1,255 @@
+// TGeoIP main application by wbx.
+// Fetches Telegram's IP ranges, finds reachable IPs, and sorts them by country.
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+
+	"github.com/babywbx/TGeoIP/pkg/diff"
+	"github.com/babywbx/TGeoIP/pkg/expand"
+	"github.com/babywbx/TGeoIP/pkg/geo"
+	"github.com/babywbx/TGeoIP/pkg/output"
+	"github.com/babywbx/TGeoIP/pkg/probe"
+	"github.com/babywbx/TGeoIP/pkg/source"
+)
+
+// Configuration Constants
+const (
+	// CidrListURL is the source for Telegram's official IP ranges.
+	CidrListURL = "https://core.telegram.org/resources/cidr.txt"
+	// MaxCheckers is the number of concurrent check operations.
+	MaxCheckers = 200
+	// CheckPort is the TCP port for connectivity tests.
+	CheckPort = "443"
+	// OutputFolder is the directory where result files are saved.
+	OutputFolder = "geoip"
+	// ProbeAttempts is how many times a single IP is retried before it's given up on.
+	ProbeAttempts = 3
+	// DefaultGroupBy is the default value of the -group-by flag: produce every
+	// supported grouping.
+	DefaultGroupBy = "country,asn,continent"
+	// DefaultTopK is the default value of the -top flag: how many of the fastest IPs
+	// per country go into the "-best.txt" files.
+	DefaultTopK = 10
+)
+
+// logger is the structured logger used throughout the pipeline. It is configured in
+// main from the -log-level and -log-format flags before any stage runs.
+var logger *slog.Logger
+
+// newLogger builds a slog.Logger for the given level ("debug", "info", "warn",
+// "error") and format ("console" or "json").
+func newLogger(level, format string) *slog.Logger {
+	var slogLevel slog.Level
+	switch strings.ToLower(level) {
+	case "debug":
+		slogLevel = slog.LevelDebug
+	case "warn":
+		slogLevel = slog.LevelWarn
+	case "error":
+		slogLevel = slog.LevelError
+	default:
+		slogLevel = slog.LevelInfo
+	}
+
+	opts := &slog.HandlerOptions{Level: slogLevel}
+	var handler slog.Handler
+	if strings.ToLower(format) == "json" {
+		handler = slog.NewJSONHandler(os.Stdout, opts)
+	} else {
+		handler = slog.NewTextHandler(os.Stdout, opts)
+	}
+	return slog.New(handler)
+}
+
+// fatal logs msg plus args at error level through the structured logger, then exits
+// non-zero, so fatal failures carry the same context fields as every other log line.
+func fatal(msg string, args ...any) {
+	logger.Error(msg, args...)
+	os.Exit(1)
+}
+
+// main is the application entry point. It wires the pkg/source, pkg/expand,
+// pkg/probe, pkg/geo, and pkg/output packages into the CIDR-to-sorted-IP pipeline.
+func main() {
+	// Flag Definitions
+	// Defines a -local flag for switching between execution modes.
+	localMode := flag.Bool("local", false, "Enable local mode to use local DB file.")
+	// Defines an -icmp flag to switch to ICMP ping mode.
+	useICMP := flag.Bool("icmp", false, "Use ICMP ping instead of the default TCP check.")
+	// Defines a -limit flag to limit the number of IPs to check.
+	limit := flag.Int("limit", 0, "Limit the number of IPs to check (0 means no limit).")
+	// Defines a -skip-check flag to skip the connectivity check.
+	skipCheck := flag.Bool("skip-check", false, "Skip connectivity check and classify all expanded IPs.")
+	// Defines -v6-edge and -v6-samples flags to control IPv6 sampling.
+	v6Edge := flag.Int("v6-edge", expand.DefaultEdgeSamples, "Number of addresses to sample from each end of a large IPv6 prefix.")
+	v6Samples := flag.Int("v6-samples", expand.DefaultRandomSamples, "Number of pseudo-random addresses to sample per large IPv6 prefix.")
+	// Defines a -group-by flag to pick which output groupings get written.
+	groupBy := flag.String("group-by", DefaultGroupBy, "Comma-separated list of groupings to write: country, asn, continent.")
+	// Defines -log-level and -log-format flags to control the structured logger.
+	logLevel := flag.String("log-level", "info", "Log level: debug, info, warn, or error.")
+	logFormat := flag.String("log-format", "console", "Log format: console or json.")
+	// Defines a -top flag to control how many fastest IPs per country go into the
+	// "-best.txt" files.
+	topK := flag.Int("top", DefaultTopK, "Number of fastest IPs per country to include in the \"-best.txt\" files.")
+	// Defines -diff, -fail-on-change, and -diff-threshold flags to compare this run
+	// against a previous one.
+	diffDir := flag.String("diff", "", "Compare the new output against a previous geoip/ directory (<prev-dir>) and write CHANGES.md / changes.json.")
+	failOnChange := flag.Bool("fail-on-change", false, "With -diff, exit non-zero if the address set changed beyond -diff-threshold.")
+	diffThreshold := flag.Int("diff-threshold", 0, "With -fail-on-change, only fail when more than N addresses moved.")
+	flag.Parse()
+
+	logger = newLogger(*logLevel, *logFormat)
+	runStart := time.Now()
+	ctx := context.Background()
+
+	// Mode-dependent setup
+	var dbPath string
+	if *localMode {
+		logger.Info("running in local mode")
+		dbPath = "ipinfo_lite.mmdb" // Use local DB file.
+	} else {
+		logger.Info("running in GitHub Actions mode")
+		dbPath = os.Getenv("DB_PATH") // Use DB path from environment variable.
+		if dbPath == "" {
+			fatal("DB_PATH environment variable not set")
+		}
+	}
+
+	// Load GeoIP database
+	logger.Info("loading GeoIP database", "db_path", dbPath)
+	db, err := maxminddb.Open(dbPath)
+	if err != nil {
+		fatal("cannot open MMDB file", "db_path", dbPath, "error", err)
+	}
+	defer db.Close()
+
+	// Main Execution Logic
+	// Load CIDR list from source
+	logger.Info("stage started", "stage", "load")
+	loadStart := time.Now()
+	cidrSource := source.NewTelegramCIDRSource(CidrListURL)
+	prefixes, err := cidrSource.Fetch(ctx)
+	if err != nil {
+		fatal("failed to load CIDR list", "stage", "load", "error", err)
+	}
+	ipv4Count, ipv6Count := countByFamily(prefixes)
+	logger.Info("stage completed", "stage", "load", "elapsed_ms", time.Since(loadStart).Milliseconds(),
+		"ipv4_cidrs", ipv4Count, "ipv6_cidrs", ipv6Count)
+
+	// Expand CIDRs to all host IPs
+	logger.Info("stage started", "stage", "expand")
+	expandStart := time.Now()
+	allIPs := expand.Hosts(prefixes)
+	allIPs = append(allIPs, expand.SampleIPv6Hosts(prefixes, *v6Edge, *v6Samples)...)
+	logger.Info("stage completed", "stage", "expand", "elapsed_ms", time.Since(expandStart).Milliseconds(), "expanded", len(allIPs))
+
+	// Apply the IP limit if the -limit flag is used.
+	if *limit > 0 && len(allIPs) > *limit {
+		logger.Info("limiting IPs to check", "limit", *limit)
+		allIPs = allIPs[:*limit]
+	}
+
+	// Conditionally check for reachable IPs or use all of them.
+	var reachable []probe.ReachableIP
+	if *skipCheck {
+		logger.Info("skipping connectivity check", "stage", "check")
+		reachable = make([]probe.ReachableIP, len(allIPs))
+		for i, ip := range allIPs {
+			reachable[i] = probe.ReachableIP{IP: ip}
+		}
+	} else {
+		// Find reachable IPs
+		logger.Info("stage started", "stage", "check")
+		checkStart := time.Now()
+		pool := probe.NewPool(newProber(*useICMP), MaxCheckers, ProbeAttempts)
+		pool.Log = logger
+		reachable = pool.Run(ctx, allIPs)
+		logger.Info("stage completed", "stage", "check", "elapsed_ms", time.Since(checkStart).Milliseconds(),
+			"checked", len(allIPs), "reachable", len(reachable))
+	}
+
+	// Group and save results
+	if len(reachable) > 0 {
+		logger.Info("stage started", "stage", "group")
+		groupStart := time.Now()
+		groups := geo.ParseGroupBy(*groupBy)
+		infos := geo.BuildIndex(reachable, db, logger)
+
+		var countryCount int
+		if groups["country"] {
+			countryMapV4, countryMapV6 := geo.ByCountry(infos)
+			countryCount = len(countryMapV4) + len(countryMapV6)
+			logger.Info("saving country groups", "stage", "group", "countries_v4", len(countryMapV4), "countries_v6", len(countryMapV6))
+			output.SaveCountryResults(OutputFolder, countryMapV4, countryMapV6, *topK, logger)
+		}
+		if groups["asn"] {
+			asnMap := geo.ByASN(infos)
+			logger.Info("saving ASN groups", "stage", "group", "asns", len(asnMap))
+			output.SaveGroupFiles(filepath.Join(OutputFolder, "ASN"), asnMap, true, logger)
+		}
+		if groups["continent"] {
+			continentMap := geo.ByContinent(infos)
+			logger.Info("saving continent groups", "stage", "group", "continents", len(continentMap))
+			output.SaveGroupFiles(filepath.Join(OutputFolder, "continent"), continentMap, false, logger)
+		}
+
+		output.SaveSummary(OutputFolder, infos, logger)
+		logger.Info("stage completed", "stage", "group", "elapsed_ms", time.Since(groupStart).Milliseconds(), "countries", countryCount)
+	} else {
+		logger.Warn("no IPs to process or save")
+	}
+
+	// Optionally diff the new output against a previous run.
+	if *diffDir != "" {
+		logger.Info("stage started", "stage", "diff")
+		diffStart := time.Now()
+		report, err := diff.Compare(*diffDir, OutputFolder)
+		if err != nil {
+			fatal("failed to compute diff", "stage", "diff", "error", err)
+		}
+		if err := diff.WriteMarkdown(report, filepath.Join(OutputFolder, "CHANGES.md")); err != nil {
+			logger.Error("error writing CHANGES.md", "stage", "diff", "error", err)
+		}
+		if err := diff.WriteJSON(report, filepath.Join(OutputFolder, "changes.json")); err != nil {
+			logger.Error("error writing changes.json", "stage", "diff", "error", err)
+		}
+		logger.Info("stage completed", "stage", "diff", "elapsed_ms", time.Since(diffStart).Milliseconds(),
+			"added", report.AddedTotal, "removed", report.RemovedTotal)
+
+		if *failOnChange && report.Moved() > *diffThreshold {
+			fatal("geoip set changed beyond threshold", "stage", "diff", "moved", report.Moved(), "threshold", *diffThreshold)
+		}
+	}
+
+	logger.Info("process completed", "elapsed_ms", time.Since(runStart).Milliseconds())
+}
+
+// newProber picks the TCP or ICMP Prober implementation based on the -icmp flag.
+func newProber(useICMP bool) probe.Prober {
+	if useICMP {
+		return probe.NewICMPProber(2 * time.Second)
+	}
+	return probe.NewTCPProber(CheckPort, 2*time.Second)
+}
+
+// countByFamily reports how many of prefixes are IPv4 versus IPv6, for stage logging.
+func countByFamily(prefixes []netip.Prefix) (ipv4, ipv6 int) {
+	for _, p := range prefixes {
+		if p.Addr().Is4() {
+			ipv4++
+		} else {
+			ipv6++
+		}
+	}
+	return ipv4, ipv6
+}
@@ -0,0 +1,208 @@
+// Package diff compares two TGeoIP output directories and reports per-country CIDR
+// and address changes, so CI jobs can detect (and optionally gate on) Telegram
+// renumbering its ranges.
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"inet.af/netaddr"
+)
+
+// CountryChange describes the CIDRs and addresses added/removed for one country (or
+// "<CC>-v6" stack) between two runs.
+type CountryChange struct {
+	Country      string   `json:"country"`
+	AddedCIDRs   []string `json:"added_cidrs,omitempty"`
+	RemovedCIDRs []string `json:"removed_cidrs,omitempty"`
+	AddedIPs     int      `json:"added_ips"`
+	RemovedIPs   int      `json:"removed_ips"`
+}
+
+// Report is the full result of comparing two output directories.
+type Report struct {
+	Changes      []CountryChange `json:"changes"`
+	AddedTotal   int             `json:"added_total"`
+	RemovedTotal int             `json:"removed_total"`
+}
+
+// Moved is the total number of addresses added plus removed, the quantity
+// -diff-threshold is compared against.
+func (r Report) Moved() int {
+	return r.AddedTotal + r.RemovedTotal
+}
+
+// cidrFilePattern matches a per-country CIDR file name, e.g. "CN-CIDR.txt" or
+// "CN-v6-CIDR.txt", and captures the country code plus any IPv6 suffix.
+var cidrFilePattern = regexp.MustCompile(`^([A-Za-z]{2})(-v6)?-CIDR\.txt$`)
+
+// Compare loads every "<CC>-CIDR.txt" / "<CC>-v6-CIDR.txt" file under prevDir and
+// newDir and reports, per country/stack, which CIDRs (and how many addresses) were
+// added or removed.
+func Compare(prevDir, newDir string) (Report, error) {
+	prevSets, err := loadCIDRSets(prevDir)
+	if err != nil {
+		return Report{}, err
+	}
+	newSets, err := loadCIDRSets(newDir)
+	if err != nil {
+		return Report{}, err
+	}
+
+	keys := make(map[string]bool)
+	for k := range prevSets {
+		keys[k] = true
+	}
+	for k := range newSets {
+		keys[k] = true
+	}
+
+	var report Report
+	for key := range keys {
+		added := setDifference(newSets[key], prevSets[key])
+		removed := setDifference(prevSets[key], newSets[key])
+		if len(added) == 0 && len(removed) == 0 {
+			continue
+		}
+
+		addedIPs := countAddresses(added)
+		removedIPs := countAddresses(removed)
+		report.Changes = append(report.Changes, CountryChange{
+			Country:      key,
+			AddedCIDRs:   prefixStrings(added),
+			RemovedCIDRs: prefixStrings(removed),
+			AddedIPs:     addedIPs,
+			RemovedIPs:   removedIPs,
+		})
+		report.AddedTotal += addedIPs
+		report.RemovedTotal += removedIPs
+	}
+
+	sort.Slice(report.Changes, func(i, j int) bool { return report.Changes[i].Country < report.Changes[j].Country })
+	return report, nil
+}
+
+// loadCIDRSets reads every "<CC>-CIDR.txt" / "<CC>-v6-CIDR.txt" file in dir into a
+// netaddr.IPSet, keyed by the file's country/stack ("CN", "CN-v6", ...).
+func loadCIDRSets(dir string) (map[string]*netaddr.IPSet, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return map[string]*netaddr.IPSet{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sets := make(map[string]*netaddr.IPSet)
+	for _, entry := range entries {
+		match := cidrFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		key := match[1] + match[2]
+
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		var builder netaddr.IPSetBuilder
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			if p, err := netaddr.ParseIPPrefix(line); err == nil {
+				builder.AddPrefix(p)
+			}
+		}
+		set, err := builder.IPSet()
+		if err != nil {
+			return nil, err
+		}
+		sets[key] = set
+	}
+	return sets, nil
+}
+
+// setDifference returns the prefixes present in a but not in b (a and/or b may be
+// nil, meaning "no data for this side").
+func setDifference(a, b *netaddr.IPSet) []netaddr.IPPrefix {
+	if a == nil {
+		return nil
+	}
+	var builder netaddr.IPSetBuilder
+	builder.AddSet(a)
+	if b != nil {
+		builder.RemoveSet(b)
+	}
+	diffSet, err := builder.IPSet()
+	if err != nil || diffSet == nil {
+		return nil
+	}
+	return diffSet.Prefixes()
+}
+
+// countAddresses sums the number of addresses covered by prefixes.
+func countAddresses(prefixes []netaddr.IPPrefix) int {
+	total := new(big.Int)
+	for _, p := range prefixes {
+		addrBits := 32
+		if p.IP().Is6() {
+			addrBits = 128
+		}
+		total.Add(total, new(big.Int).Lsh(big.NewInt(1), uint(addrBits-int(p.Bits()))))
+	}
+	if !total.IsInt64() {
+		return math.MaxInt64
+	}
+	return int(total.Int64())
+}
+
+// prefixStrings renders prefixes as sorted CIDR strings.
+func prefixStrings(prefixes []netaddr.IPPrefix) []string {
+	strs := make([]string, len(prefixes))
+	for i, p := range prefixes {
+		strs[i] = p.String()
+	}
+	sort.Strings(strs)
+	return strs
+}
+
+// WriteMarkdown writes a human-readable CHANGES.md summarizing report to path.
+func WriteMarkdown(report Report, path string) error {
+	var b strings.Builder
+	b.WriteString("# GeoIP Changes\n\n")
+	if len(report.Changes) == 0 {
+		b.WriteString("No changes detected.\n")
+	}
+	for _, c := range report.Changes {
+		fmt.Fprintf(&b, "## %s\n\n", c.Country)
+		fmt.Fprintf(&b, "%d address(es) added, %d address(es) removed\n\n", c.AddedIPs, c.RemovedIPs)
+		for _, cidr := range c.AddedCIDRs {
+			fmt.Fprintf(&b, "- `+%s`\n", cidr)
+		}
+		for _, cidr := range c.RemovedCIDRs {
+			fmt.Fprintf(&b, "- `-%s`\n", cidr)
+		}
+		b.WriteString("\n")
+	}
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
+
+// WriteJSON writes report as changes.json to path.
+func WriteJSON(report Report, path string) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,82 @@
+package diff
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeCIDRFile(t *testing.T, dir, name, contents string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+		t.Fatalf("writing %s: %v", name, err)
+	}
+}
+
+func TestCompareAddedAndRemoved(t *testing.T) {
+	prevDir := t.TempDir()
+	newDir := t.TempDir()
+
+	// CN loses 203.0.113.0/25 and gains 198.51.100.0/25.
+	writeCIDRFile(t, prevDir, "CN-CIDR.txt", "203.0.113.0/24\n")
+	writeCIDRFile(t, newDir, "CN-CIDR.txt", "203.0.113.0/25\n198.51.100.0/25\n")
+
+	// US is unchanged.
+	writeCIDRFile(t, prevDir, "US-CIDR.txt", "192.0.2.0/24\n")
+	writeCIDRFile(t, newDir, "US-CIDR.txt", "192.0.2.0/24\n")
+
+	report, err := Compare(prevDir, newDir)
+	if err != nil {
+		t.Fatalf("Compare: %v", err)
+	}
+
+	if len(report.Changes) != 1 {
+		t.Fatalf("len(report.Changes) = %d, want 1 (only CN changed): %+v", len(report.Changes), report.Changes)
+	}
+
+	c := report.Changes[0]
+	if c.Country != "CN" {
+		t.Fatalf("report.Changes[0].Country = %q, want CN", c.Country)
+	}
+	if c.AddedIPs != 128 {
+		t.Errorf("c.AddedIPs = %d, want 128", c.AddedIPs)
+	}
+	if c.RemovedIPs != 128 {
+		t.Errorf("c.RemovedIPs = %d, want 128", c.RemovedIPs)
+	}
+	if report.AddedTotal != 128 || report.RemovedTotal != 128 {
+		t.Errorf("report totals = +%d/-%d, want +128/-128", report.AddedTotal, report.RemovedTotal)
+	}
+	if report.Moved() != 256 {
+		t.Errorf("report.Moved() = %d, want 256", report.Moved())
+	}
+}
+
+func TestCompareNoChanges(t *testing.T) {
+	prevDir := t.TempDir()
+	newDir := t.TempDir()
+
+	writeCIDRFile(t, prevDir, "CN-CIDR.txt", "203.0.113.0/24\n")
+	writeCIDRFile(t, newDir, "CN-CIDR.txt", "203.0.113.0/24\n")
+
+	report, err := Compare(prevDir, newDir)
+	if err != nil {
+		t.Fatalf("Compare: %v", err)
+	}
+	if len(report.Changes) != 0 || report.Moved() != 0 {
+		t.Fatalf("expected no changes, got %+v", report)
+	}
+}
+
+func TestCompareMissingPrevDir(t *testing.T) {
+	newDir := t.TempDir()
+	writeCIDRFile(t, newDir, "CN-CIDR.txt", "203.0.113.0/24\n")
+
+	report, err := Compare(filepath.Join(t.TempDir(), "does-not-exist"), newDir)
+	if err != nil {
+		t.Fatalf("Compare: %v", err)
+	}
+	if len(report.Changes) != 1 || report.Changes[0].AddedIPs != 256 {
+		t.Fatalf("expected CN fully added (256 addresses), got %+v", report)
+	}
+}
@@ -0,0 +1,190 @@
+// Package expand turns CIDR prefixes into concrete host addresses to probe. IPv4
+// prefixes are fully enumerated; IPv6 prefixes wider than IPv6FullEnumerationThreshold
+// host bits are sampled instead, since full enumeration of a /64 or larger is
+// infeasible.
+package expand
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"net"
+	"net/netip"
+)
+
+const (
+	// IPv6FullEnumerationThreshold is the largest host-bit count for which an IPv6
+	// prefix is fully enumerated instead of sampled (256 addresses).
+	IPv6FullEnumerationThreshold = 8
+	// DefaultEdgeSamples is the default number of addresses sampled from each end of
+	// a large IPv6 prefix.
+	DefaultEdgeSamples = 2
+	// DefaultRandomSamples is the default number of pseudo-random addresses sampled
+	// from the middle of a large IPv6 prefix.
+	DefaultRandomSamples = 4
+)
+
+// Hosts expands the IPv4 prefixes in prefixes into every usable host address, dropping
+// the network and broadcast addresses for prefixes with more than two addresses.
+// Non-IPv4 prefixes are ignored.
+func Hosts(prefixes []netip.Prefix) []string {
+	var allIPs []string
+	for _, p := range prefixes {
+		if !p.Addr().Is4() {
+			continue
+		}
+		ip, ipnet, err := net.ParseCIDR(p.String())
+		if err != nil {
+			continue
+		}
+		var currentIPs []string
+		for addr := ip.Mask(ipnet.Mask); ipnet.Contains(addr); incrementIP(addr) {
+			addrCopy := make(net.IP, len(addr))
+			copy(addrCopy, addr)
+			currentIPs = append(currentIPs, addrCopy.String())
+		}
+		if len(currentIPs) > 2 {
+			allIPs = append(allIPs, currentIPs[1:len(currentIPs)-1]...)
+		} else {
+			allIPs = append(allIPs, currentIPs...)
+		}
+	}
+	return allIPs
+}
+
+// SampleIPv6Hosts expands the IPv6 prefixes in prefixes into a bounded, deterministic
+// set of host addresses: full enumeration for prefixes with at most
+// IPv6FullEnumerationThreshold host bits, and edgeSamples addresses from each end plus
+// randomSamples pseudo-random addresses (seeded from the prefix, so runs are
+// reproducible) for anything larger. Non-IPv6 prefixes are ignored.
+func SampleIPv6Hosts(prefixes []netip.Prefix, edgeSamples, randomSamples int) []string {
+	var allIPs []string
+	for _, p := range prefixes {
+		if p.Addr().Is4() {
+			continue
+		}
+		_, ipnet, err := net.ParseCIDR(p.String())
+		if err != nil {
+			continue
+		}
+		ones, bits := ipnet.Mask.Size()
+		hostBits := bits - ones
+		if hostBits <= IPv6FullEnumerationThreshold {
+			for addr := ipnet.IP.Mask(ipnet.Mask); ipnet.Contains(addr); incrementIP(addr) {
+				addrCopy := make(net.IP, len(addr))
+				copy(addrCopy, addr)
+				allIPs = append(allIPs, addrCopy.String())
+			}
+			continue
+		}
+		allIPs = append(allIPs, samplePrefix(ipnet, hostBits, edgeSamples, randomSamples)...)
+	}
+	return allIPs
+}
+
+// samplePrefix returns the first edgeSamples and last edgeSamples usable addresses in
+// ipnet, plus randomSamples pseudo-random addresses drawn from a PRNG seeded from the
+// prefix so the sample is reproducible across runs.
+func samplePrefix(ipnet *net.IPNet, hostBits, edgeSamples, randomSamples int) []string {
+	var samples []string
+	seen := make(map[string]bool)
+
+	add := func(addr net.IP) {
+		s := addr.String()
+		if !seen[s] {
+			seen[s] = true
+			samples = append(samples, s)
+		}
+	}
+
+	network := ipnet.IP.Mask(ipnet.Mask)
+
+	// First N addresses.
+	first := make(net.IP, len(network))
+	copy(first, network)
+	for i := 0; i < edgeSamples; i++ {
+		add(first)
+		first = cloneAndIncrement(first)
+	}
+
+	// Last N addresses: broadcast = network | ^mask.
+	broadcast := make(net.IP, len(network))
+	for i := range broadcast {
+		broadcast[i] = network[i] | ^ipnet.Mask[i]
+	}
+	last := make(net.IP, len(broadcast))
+	copy(last, broadcast)
+	for i := 0; i < edgeSamples; i++ {
+		add(last)
+		last = cloneAndDecrement(last)
+	}
+
+	// Pseudo-random addresses within the host portion, seeded from the prefix.
+	rng := rand.New(rand.NewSource(seedFromPrefix(ipnet)))
+	for i := 0; i < randomSamples; i++ {
+		addr := make(net.IP, len(network))
+		copy(addr, network)
+		randomizeHostBits(addr, hostBits, rng)
+		add(addr)
+	}
+
+	return samples
+}
+
+// seedFromPrefix derives a deterministic PRNG seed from a prefix's network address and
+// mask so that sampling the same prefix always produces the same pseudo-random
+// addresses.
+func seedFromPrefix(ipnet *net.IPNet) int64 {
+	h := fnv.New64a()
+	h.Write(ipnet.IP)
+	h.Write(ipnet.Mask)
+	return int64(h.Sum64())
+}
+
+// randomizeHostBits overwrites the low hostBits bits of addr with random bits from
+// rng, leaving the network portion untouched.
+func randomizeHostBits(addr net.IP, hostBits int, rng *rand.Rand) {
+	randomBytes := make([]byte, len(addr))
+	rng.Read(randomBytes)
+	for i := 0; i < hostBits; i++ {
+		byteIdx := len(addr) - 1 - i/8
+		bitIdx := uint(i % 8)
+		bitMask := byte(1) << bitIdx
+		addr[byteIdx] = (addr[byteIdx] &^ bitMask) | (randomBytes[byteIdx] & bitMask)
+	}
+}
+
+// cloneAndIncrement returns a copy of ip incremented by one, leaving ip untouched.
+func cloneAndIncrement(ip net.IP) net.IP {
+	next := make(net.IP, len(ip))
+	copy(next, ip)
+	incrementIP(next)
+	return next
+}
+
+// cloneAndDecrement returns a copy of ip decremented by one, leaving ip untouched.
+func cloneAndDecrement(ip net.IP) net.IP {
+	prev := make(net.IP, len(ip))
+	copy(prev, ip)
+	decrementIP(prev)
+	return prev
+}
+
+// incrementIP treats an IP address as a big-endian integer and increments it by one.
+func incrementIP(ip net.IP) {
+	for j := len(ip) - 1; j >= 0; j-- {
+		ip[j]++
+		if ip[j] > 0 {
+			break
+		}
+	}
+}
+
+// decrementIP treats an IP address as a big-endian integer and decrements it by one.
+func decrementIP(ip net.IP) {
+	for j := len(ip) - 1; j >= 0; j-- {
+		ip[j]--
+		if ip[j] < 0xff {
+			break
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package expand
+
+import (
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func TestSampleIPv6HostsDeterministic(t *testing.T) {
+	prefixes := []netip.Prefix{netip.MustParsePrefix("2001:db8::/32")}
+
+	first := SampleIPv6Hosts(prefixes, DefaultEdgeSamples, DefaultRandomSamples)
+	second := SampleIPv6Hosts(prefixes, DefaultEdgeSamples, DefaultRandomSamples)
+
+	if !reflect.DeepEqual(first, second) {
+		t.Fatalf("SampleIPv6Hosts is not deterministic: %v != %v", first, second)
+	}
+	if len(first) == 0 {
+		t.Fatal("SampleIPv6Hosts returned no addresses for a /32 prefix")
+	}
+}
+
+func TestSampleIPv6HostsIgnoresIPv4(t *testing.T) {
+	prefixes := []netip.Prefix{netip.MustParsePrefix("203.0.113.0/24")}
+
+	got := SampleIPv6Hosts(prefixes, DefaultEdgeSamples, DefaultRandomSamples)
+	if len(got) != 0 {
+		t.Fatalf("SampleIPv6Hosts should ignore IPv4 prefixes, got %v", got)
+	}
+}
+
+func TestHostsDropsNetworkAndBroadcast(t *testing.T) {
+	prefixes := []netip.Prefix{netip.MustParsePrefix("203.0.113.0/30")}
+
+	got := Hosts(prefixes)
+	want := []string{"203.0.113.1", "203.0.113.2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Hosts(%v) = %v, want %v", prefixes, got, want)
+	}
+}
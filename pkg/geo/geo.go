@@ -0,0 +1,129 @@
+// Package geo enriches reachable IPs with MaxMindDB lookups (country, ASN, continent)
+// and groups them for the various output files.
+package geo
+
+import (
+	"log/slog"
+	"net"
+	"strings"
+
+	"github.com/oschwald/maxminddb-golang"
+
+	"github.com/babywbx/TGeoIP/pkg/probe"
+)
+
+// Record is the MMDB lookup result. ipinfo's "lite" database exposes these fields in
+// addition to the country code; other region/city fields are left for a richer
+// database to fill in.
+type Record struct {
+	CountryCode   string `maxminddb:"country_code"`
+	ContinentCode string `maxminddb:"continent_code"`
+	ASN           string `maxminddb:"asn"`
+	ASName        string `maxminddb:"as_name"`
+	ASDomain      string `maxminddb:"as_domain"`
+}
+
+// Info holds the enrichment data and probe result for a single reachable IP.
+type Info struct {
+	IP            string
+	CountryCode   string
+	ContinentCode string
+	ASN           string
+	ASName        string
+	RTTms         float64
+	Attempts      int
+}
+
+// BuildIndex looks up every reachable IP in db once and returns the enrichment data
+// (country, ASN, continent, RTT, ...) for each, so that grouping and the summary.json
+// output can all be derived without repeating lookups. An IP is kept as long as the
+// MMDB returned any of country/ASN/continent for it — anycast and hosting ranges often
+// have ASN/continent data but no country, and BuildIndex shouldn't drop them just
+// because ByCountry can't place them; ByCountry/ByASN/ByContinent each skip whichever
+// field is empty. log, if non-nil, receives a debug-level event for every lookup.
+func BuildIndex(reachable []probe.ReachableIP, db *maxminddb.Reader, log *slog.Logger) []Info {
+	var infos []Info
+	for _, r := range reachable {
+		ip := net.ParseIP(r.IP)
+		if ip == nil {
+			continue
+		}
+		var record Record
+		if err := db.Lookup(ip, &record); err != nil {
+			continue
+		}
+		if record.CountryCode == "" && record.ASN == "" && record.ContinentCode == "" {
+			continue
+		}
+		if log != nil {
+			log.Debug("looked up IP", "ip", r.IP, "country", record.CountryCode, "asn", record.ASN)
+		}
+		infos = append(infos, Info{
+			IP:            r.IP,
+			CountryCode:   record.CountryCode,
+			ContinentCode: record.ContinentCode,
+			ASN:           record.ASN,
+			ASName:        record.ASName,
+			RTTms:         r.RTTms,
+			Attempts:      r.Attempts,
+		})
+	}
+	return infos
+}
+
+// ByCountry buckets infos by country code, returning separate maps for IPv4 and IPv6
+// addresses so callers can write per-stack files. IPs the MMDB had no country for are
+// skipped.
+func ByCountry(infos []Info) (v4, v6 map[string][]Info) {
+	v4 = make(map[string][]Info)
+	v6 = make(map[string][]Info)
+	for _, info := range infos {
+		if info.CountryCode == "" {
+			continue
+		}
+		if net.ParseIP(info.IP).To4() != nil {
+			v4[info.CountryCode] = append(v4[info.CountryCode], info)
+		} else {
+			v6[info.CountryCode] = append(v6[info.CountryCode], info)
+		}
+	}
+	return v4, v6
+}
+
+// ByASN buckets infos by ASN (e.g. "AS62041"), skipping IPs the MMDB had no ASN for.
+func ByASN(infos []Info) map[string][]string {
+	asnMap := make(map[string][]string)
+	for _, info := range infos {
+		if info.ASN == "" {
+			continue
+		}
+		asnMap[info.ASN] = append(asnMap[info.ASN], info.IP)
+	}
+	return asnMap
+}
+
+// ByContinent buckets infos by continent code (e.g. "EU"), skipping IPs the MMDB had
+// no continent for.
+func ByContinent(infos []Info) map[string][]string {
+	continentMap := make(map[string][]string)
+	for _, info := range infos {
+		if info.ContinentCode == "" {
+			continue
+		}
+		continentMap[info.ContinentCode] = append(continentMap[info.ContinentCode], info.IP)
+	}
+	return continentMap
+}
+
+// ParseGroupBy turns a comma-separated -group-by value into a set of enabled
+// groupings.
+func ParseGroupBy(groupBy string) map[string]bool {
+	groups := make(map[string]bool)
+	for _, g := range strings.Split(groupBy, ",") {
+		g = strings.TrimSpace(g)
+		if g != "" {
+			groups[g] = true
+		}
+	}
+	return groups
+}
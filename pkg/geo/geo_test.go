@@ -0,0 +1,62 @@
+package geo
+
+import "testing"
+
+func TestByASNSkipsEmpty(t *testing.T) {
+	infos := []Info{
+		{IP: "1.1.1.1", ASN: "AS123"},
+		{IP: "1.1.1.2", ASN: ""},
+		{IP: "1.1.1.3", ASN: "AS123"},
+	}
+
+	got := ByASN(infos)
+	if len(got) != 1 {
+		t.Fatalf("ByASN returned %d keys, want 1: %+v", len(got), got)
+	}
+	want := []string{"1.1.1.1", "1.1.1.3"}
+	if !equalStrings(got["AS123"], want) {
+		t.Errorf("ByASN[AS123] = %v, want %v", got["AS123"], want)
+	}
+	if _, ok := got[""]; ok {
+		t.Errorf("ByASN should not have an entry for IPs with no ASN")
+	}
+}
+
+func TestByContinentSkipsEmpty(t *testing.T) {
+	infos := []Info{
+		{IP: "1.1.1.1", ContinentCode: "EU"},
+		{IP: "1.1.1.2", ContinentCode: ""},
+	}
+
+	got := ByContinent(infos)
+	if len(got) != 1 {
+		t.Fatalf("ByContinent returned %d keys, want 1: %+v", len(got), got)
+	}
+	if !equalStrings(got["EU"], []string{"1.1.1.1"}) {
+		t.Errorf("ByContinent[EU] = %v, want [1.1.1.1]", got["EU"])
+	}
+}
+
+func TestParseGroupBy(t *testing.T) {
+	got := ParseGroupBy(" country, asn ,,continent")
+	for _, want := range []string{"country", "asn", "continent"} {
+		if !got[want] {
+			t.Errorf("ParseGroupBy(...)[%q] = false, want true", want)
+		}
+	}
+	if len(got) != 3 {
+		t.Errorf("ParseGroupBy(...) has %d entries, want 3: %+v", len(got), got)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
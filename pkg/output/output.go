@@ -0,0 +1,190 @@
+// Package output writes the pipeline's result files: per-country/ASN/continent IP and
+// CIDR lists, RTT rankings, and the summary.json index.
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"inet.af/netaddr"
+
+	"github.com/babywbx/TGeoIP/pkg/geo"
+)
+
+// SummaryEntry is the per-IP payload written to summary.json.
+type SummaryEntry struct {
+	Country   string  `json:"country"`
+	ASN       string  `json:"asn"`
+	ASName    string  `json:"as_name"`
+	Continent string  `json:"continent"`
+	RTTms     float64 `json:"rtt_ms"`
+}
+
+// SaveCountryResults creates dir and saves the country result files after sorting
+// them. IPv4 results are written as "<CC>.txt"/"<CC>-CIDR.txt" and IPv6 results as
+// "<CC>-v6.txt"/"<CC>-v6-CIDR.txt" so consumers can pick a stack. Each stack also gets
+// a "-ranked.txt" file (IP and RTT, sorted ascending) and a "-best.txt" file with the
+// topK fastest IPs. log, if non-nil, receives write errors.
+func SaveCountryResults(dir string, v4Data, v6Data map[string][]geo.Info, topK int, log *slog.Logger) {
+	os.MkdirAll(dir, 0755)
+	saveCountryFiles(dir, v4Data, "%s/%s.txt", "%s/%s-CIDR.txt", "%s/%s-ranked.txt", "%s/%s-best.txt", topK, log)
+	saveCountryFiles(dir, v6Data, "%s/%s-v6.txt", "%s/%s-v6-CIDR.txt", "%s/%s-v6-ranked.txt", "%s/%s-v6-best.txt", topK, log)
+}
+
+// saveCountryFiles writes the plain-IP, aggregated-CIDR, RTT-ranked, and top-K files
+// for each country in data, using the given patterns (each taking dir and the country
+// code) for filenames.
+func saveCountryFiles(dir string, data map[string][]geo.Info, ipPattern, cidrPattern, rankedPattern, bestPattern string, topK int, log *slog.Logger) {
+	for country, infos := range data {
+		ipList := make([]string, len(infos))
+		for i, info := range infos {
+			ipList[i] = info.IP
+		}
+
+		// Sort the plain IP list before writing.
+		sortIPStrings(ipList)
+		writeLines(fmt.Sprintf(ipPattern, dir, country), ipList, log)
+
+		// Aggregate and sort the CIDR list before writing.
+		cidrList := aggregateCIDRs(ipList)
+		sortCIDRStrings(cidrList)
+		writeLines(fmt.Sprintf(cidrPattern, dir, country), cidrList, log)
+
+		// Rank by RTT ascending for the "-ranked.txt" and "-best.txt" files.
+		ranked := make([]geo.Info, len(infos))
+		copy(ranked, infos)
+		sort.Slice(ranked, func(i, j int) bool { return ranked[i].RTTms < ranked[j].RTTms })
+
+		rankedLines := make([]string, len(ranked))
+		for i, info := range ranked {
+			rankedLines[i] = fmt.Sprintf("%s %.1fms", info.IP, info.RTTms)
+		}
+		writeLines(fmt.Sprintf(rankedPattern, dir, country), rankedLines, log)
+
+		k := topK
+		if k > len(ranked) {
+			k = len(ranked)
+		}
+		bestLines := make([]string, k)
+		for i := 0; i < k; i++ {
+			bestLines[i] = ranked[i].IP
+		}
+		writeLines(fmt.Sprintf(bestPattern, dir, country), bestLines, log)
+	}
+}
+
+// SaveGroupFiles writes one "<key>.txt" file per group in data under dir, and
+// additionally a "<key>-CIDR.txt" aggregated-CIDR file when withCIDR is true. log, if
+// non-nil, receives write errors.
+func SaveGroupFiles(dir string, data map[string][]string, withCIDR bool, log *slog.Logger) {
+	os.MkdirAll(dir, 0755)
+	for key, ipList := range data {
+		sortIPStrings(ipList)
+		writeLines(filepath.Join(dir, key+".txt"), ipList, log)
+
+		if !withCIDR {
+			continue
+		}
+		cidrList := aggregateCIDRs(ipList)
+		sortCIDRStrings(cidrList)
+		writeLines(filepath.Join(dir, key+"-CIDR.txt"), cidrList, log)
+	}
+}
+
+// SaveSummary writes "<dir>/summary.json", mapping every looked-up IP to its
+// enrichment data. log, if non-nil, receives marshal/write errors.
+func SaveSummary(dir string, infos []geo.Info, log *slog.Logger) {
+	summary := make(map[string]SummaryEntry, len(infos))
+	for _, info := range infos {
+		summary[info.IP] = SummaryEntry{
+			Country:   info.CountryCode,
+			ASN:       info.ASN,
+			ASName:    info.ASName,
+			Continent: info.ContinentCode,
+			RTTms:     info.RTTms,
+		}
+	}
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		if log != nil {
+			log.Error("error marshaling summary.json", "error", err)
+		}
+		return
+	}
+	path := filepath.Join(dir, "summary.json")
+	if err := os.WriteFile(path, data, 0644); err != nil && log != nil {
+		log.Error("error writing to file", "path", path, "error", err)
+	}
+}
+
+// sortIPStrings sorts a slice of IP address strings numerically.
+func sortIPStrings(ips []string) {
+	sort.Slice(ips, func(i, j int) bool {
+		ipA := net.ParseIP(ips[i])
+		ipB := net.ParseIP(ips[j])
+		if ipA == nil || ipB == nil {
+			return ips[i] < ips[j] // Fallback to string sort if parsing fails
+		}
+		// Use To16() to ensure both IPv4 and IPv6 are compared correctly as 16-byte slices.
+		return bytes.Compare(ipA.To16(), ipB.To16()) < 0
+	})
+}
+
+// sortCIDRStrings sorts a slice of CIDR notation strings correctly.
+func sortCIDRStrings(cidrs []string) {
+	sort.Slice(cidrs, func(i, j int) bool {
+		prefixA, errA := netaddr.ParseIPPrefix(cidrs[i])
+		prefixB, errB := netaddr.ParseIPPrefix(cidrs[j])
+		if errA != nil || errB != nil {
+			return cidrs[i] < cidrs[j] // Fallback
+		}
+		// Compare IP addresses first, then prefix lengths
+		ipCompare := prefixA.IP().Compare(prefixB.IP())
+		if ipCompare != 0 {
+			return ipCompare < 0
+		}
+		return prefixA.Bits() < prefixB.Bits()
+	})
+}
+
+// aggregateCIDRs merges a list of IPs into the smallest possible set of CIDRs.
+func aggregateCIDRs(ips []string) []string {
+	var builder netaddr.IPSetBuilder
+	if ips == nil {
+		return nil
+	}
+	for _, ipStr := range ips {
+		if ip, err := netaddr.ParseIP(ipStr); err == nil {
+			builder.Add(ip)
+		}
+	}
+	ipSet, _ := builder.IPSet()
+	if ipSet == nil {
+		return nil
+	}
+	var cidrs []string
+	for _, r := range ipSet.Ranges() {
+		for _, p := range r.Prefixes() {
+			cidrs = append(cidrs, p.String())
+		}
+	}
+	return cidrs
+}
+
+// writeLines writes a slice of strings to a file without a trailing newline.
+func writeLines(filePath string, lines []string, log *slog.Logger) {
+	if len(lines) == 0 {
+		return
+	}
+	output := strings.Join(lines, "\n")
+	if err := os.WriteFile(filePath, []byte(output), 0644); err != nil && log != nil {
+		log.Error("error writing to file", "path", filePath, "error", err)
+	}
+}
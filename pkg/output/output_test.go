@@ -0,0 +1,50 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/babywbx/TGeoIP/pkg/geo"
+)
+
+func TestSaveCountryFilesRanksByRTT(t *testing.T) {
+	dir := t.TempDir()
+	data := map[string][]geo.Info{
+		"CN": {
+			{IP: "1.1.1.1", CountryCode: "CN", RTTms: 30},
+			{IP: "1.1.1.2", CountryCode: "CN", RTTms: 10},
+			{IP: "1.1.1.3", CountryCode: "CN", RTTms: 20},
+		},
+	}
+
+	saveCountryFiles(dir, data, "%s/%s.txt", "%s/%s-CIDR.txt", "%s/%s-ranked.txt", "%s/%s-best.txt", 2, nil)
+
+	ranked, err := os.ReadFile(filepath.Join(dir, "CN-ranked.txt"))
+	if err != nil {
+		t.Fatalf("reading CN-ranked.txt: %v", err)
+	}
+	rankedLines := strings.Split(string(ranked), "\n")
+	wantRanked := []string{"1.1.1.2 10.0ms", "1.1.1.3 20.0ms", "1.1.1.1 30.0ms"}
+	for i, want := range wantRanked {
+		if rankedLines[i] != want {
+			t.Errorf("CN-ranked.txt line %d = %q, want %q", i, rankedLines[i], want)
+		}
+	}
+
+	best, err := os.ReadFile(filepath.Join(dir, "CN-best.txt"))
+	if err != nil {
+		t.Fatalf("reading CN-best.txt: %v", err)
+	}
+	bestLines := strings.Split(string(best), "\n")
+	wantBest := []string{"1.1.1.2", "1.1.1.3"}
+	if len(bestLines) != len(wantBest) {
+		t.Fatalf("CN-best.txt has %d lines, want %d: %v", len(bestLines), len(wantBest), bestLines)
+	}
+	for i, want := range wantBest {
+		if bestLines[i] != want {
+			t.Errorf("CN-best.txt line %d = %q, want %q", i, bestLines[i], want)
+		}
+	}
+}
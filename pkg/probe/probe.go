@@ -0,0 +1,193 @@
+// Package probe checks whether IP addresses are reachable and measures round-trip
+// time, via either a TCP connect or an ICMP ping.
+package probe
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/netip"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Prober checks whether a single IP address is reachable and, if so, how long the
+// successful attempt took.
+type Prober interface {
+	Probe(ctx context.Context, ip netip.Addr) (reachable bool, rtt time.Duration, err error)
+}
+
+// TCPProber probes by dialing a TCP port; an established connection counts as
+// reachable, and the RTT is the time the dial took.
+type TCPProber struct {
+	Port    string
+	Timeout time.Duration
+}
+
+// NewTCPProber returns a TCPProber that dials port with the given per-attempt timeout.
+func NewTCPProber(port string, timeout time.Duration) *TCPProber {
+	return &TCPProber{Port: port, Timeout: timeout}
+}
+
+// Probe implements Prober.
+func (p *TCPProber) Probe(ctx context.Context, ip netip.Addr) (bool, time.Duration, error) {
+	start := time.Now()
+	dialer := net.Dialer{Timeout: p.Timeout}
+	// net.JoinHostPort brackets IPv6 addresses automatically, so this dials
+	// "[ipv6]:443" and "ipv4:443" without any family-specific branching.
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(ip.String(), p.Port))
+	if err != nil {
+		return false, 0, err
+	}
+	conn.Close()
+	return true, time.Since(start), nil
+}
+
+// ICMPProber probes by shelling out to the system "ping"/"ping6" binary and parsing
+// the RTT from its output, falling back to wall-clock timing if no "time=" field is
+// found.
+type ICMPProber struct {
+	Timeout time.Duration
+}
+
+// NewICMPProber returns an ICMPProber with the given per-attempt timeout.
+func NewICMPProber(timeout time.Duration) *ICMPProber {
+	return &ICMPProber{Timeout: timeout}
+}
+
+// pingRTTPattern extracts the round-trip time reported by the "ping"/"ping6"
+// binaries, e.g. "64 bytes from 1.1.1.1: icmp_seq=1 ttl=58 time=12.3 ms".
+var pingRTTPattern = regexp.MustCompile(`time[=<]([0-9.]+)`)
+
+// Probe implements Prober.
+func (p *ICMPProber) Probe(ctx context.Context, ip netip.Addr) (bool, time.Duration, error) {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, p.Timeout)
+	defer cancel()
+	name, args := pingCommandForIP(ip)
+	output, err := exec.CommandContext(ctx, name, args...).Output()
+	if err != nil {
+		return false, 0, err
+	}
+	if rttMs, ok := parsePingRTT(output); ok {
+		return true, time.Duration(rttMs * float64(time.Millisecond)), nil
+	}
+	return true, time.Since(start), nil
+}
+
+// pingCommandForIP picks the right ping binary and arguments for ip's address family.
+// Linux and BSD/macOS both accept "ping -6" for IPv6, but some minimal environments
+// (e.g. busybox, or older macOS) only understand the dedicated "ping6" binary, so we
+// prefer that form everywhere except Linux.
+func pingCommandForIP(ip netip.Addr) (string, []string) {
+	if ip.Is4() {
+		return "ping", []string{"-c", "1", "-W", "1", ip.String()}
+	}
+	if runtime.GOOS == "linux" {
+		return "ping", []string{"-6", "-c", "1", "-W", "1", ip.String()}
+	}
+	return "ping6", []string{"-c", "1", "-W", "1", ip.String()}
+}
+
+// parsePingRTT extracts the RTT in milliseconds from ping's stdout. It reports
+// ok=false if no "time=" field was found, so callers can fall back to wall-clock
+// timing.
+func parsePingRTT(output []byte) (rttMs float64, ok bool) {
+	match := pingRTTPattern.FindSubmatch(output)
+	if match == nil {
+		return 0, false
+	}
+	rttMs, err := strconv.ParseFloat(string(match[1]), 64)
+	return rttMs, err == nil
+}
+
+// ReachableIP is a probe result: an IP that answered, the minimum round-trip time
+// observed across its attempts, and how many attempts were made (always MaxAttempts,
+// since every attempt runs so RTTms reflects a true minimum).
+type ReachableIP struct {
+	IP       string
+	RTTms    float64
+	Attempts int
+}
+
+// Pool runs a Prober against many IPs concurrently, probing each IP up to MaxAttempts
+// times (with a short backoff between attempts) and keeping the minimum RTT across
+// whichever attempts succeeded. An IP is reported reachable if at least one attempt
+// succeeded, even if a later attempt times out.
+type Pool struct {
+	Prober      Prober
+	Concurrency int
+	MaxAttempts int
+	Backoff     time.Duration
+	// Log, if non-nil, receives a debug-level event for every successful attempt.
+	Log *slog.Logger
+}
+
+// NewPool returns a Pool that probes with prober, at most concurrency IPs at a time,
+// retrying each IP up to maxAttempts times.
+func NewPool(prober Prober, concurrency, maxAttempts int) *Pool {
+	return &Pool{Prober: prober, Concurrency: concurrency, MaxAttempts: maxAttempts, Backoff: 200 * time.Millisecond}
+}
+
+// Run probes every address in ips and returns the ones that answered, each with the
+// minimum RTT observed across its (up to MaxAttempts) attempts. Addresses that fail to
+// parse are skipped.
+func (p *Pool) Run(ctx context.Context, ips []string) []ReachableIP {
+	// workerIDs doubles as the concurrency semaphore (its capacity bounds how many
+	// goroutines can hold an ID at once) and gives each probe a worker_id for logging.
+	workerIDs := make(chan int, p.Concurrency)
+	for i := 0; i < p.Concurrency; i++ {
+		workerIDs <- i
+	}
+	results := make(chan ReachableIP, len(ips))
+	var wg sync.WaitGroup
+
+	for _, ipStr := range ips {
+		ip, err := netip.ParseAddr(ipStr)
+		if err != nil {
+			continue
+		}
+		wg.Add(1)
+		go func(ip netip.Addr) {
+			defer wg.Done()
+			workerID := <-workerIDs
+			defer func() { workerIDs <- workerID }()
+
+			var best time.Duration
+			reachable := false
+			attempts := 0
+			for attempt := 1; attempt <= p.MaxAttempts; attempt++ {
+				attempts = attempt
+				if ok, rtt, err := p.Prober.Probe(ctx, ip); err == nil && ok {
+					if !reachable || rtt < best {
+						best = rtt
+					}
+					reachable = true
+				}
+				if attempt < p.MaxAttempts {
+					time.Sleep(p.Backoff)
+				}
+			}
+			if reachable {
+				rttMs := float64(best.Microseconds()) / 1000
+				if p.Log != nil {
+					p.Log.Debug("ip reachable", "ip", ip.String(), "attempts", attempts, "worker_id", workerID, "latency_ms", rttMs)
+				}
+				results <- ReachableIP{IP: ip.String(), RTTms: rttMs, Attempts: attempts}
+			}
+		}(ip)
+	}
+
+	wg.Wait()
+	close(results)
+
+	var reachable []ReachableIP
+	for r := range results {
+		reachable = append(reachable, r)
+	}
+	return reachable
+}
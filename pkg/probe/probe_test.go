@@ -0,0 +1,106 @@
+package probe
+
+import (
+	"context"
+	"net/netip"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestParsePingRTT(t *testing.T) {
+	cases := []struct {
+		name   string
+		output string
+		wantMs float64
+		wantOK bool
+	}{
+		{"linux ping", "64 bytes from 1.1.1.1: icmp_seq=1 ttl=58 time=12.3 ms\n", 12.3, true},
+		{"bsd ping6", "16 bytes from 2001:db8::1, icmp_seq=0 hlim=58 time=7.891 ms\n", 7.891, true},
+		{"sub-millisecond", "64 bytes from 1.1.1.1: icmp_seq=1 ttl=58 time<1 ms\n", 1, true},
+		{"no time field", "Request timeout for icmp_seq 0\n", 0, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gotMs, ok := parsePingRTT([]byte(c.output))
+			if ok != c.wantOK {
+				t.Fatalf("parsePingRTT(%q) ok = %v, want %v", c.output, ok, c.wantOK)
+			}
+			if ok && gotMs != c.wantMs {
+				t.Errorf("parsePingRTT(%q) = %v, want %v", c.output, gotMs, c.wantMs)
+			}
+		})
+	}
+}
+
+func TestPingCommandForIP(t *testing.T) {
+	name, args := pingCommandForIP(netip.MustParseAddr("203.0.113.1"))
+	if name != "ping" || len(args) == 0 || args[len(args)-1] != "203.0.113.1" {
+		t.Errorf("pingCommandForIP(v4) = %q, %v, want \"ping\" ending in the address", name, args)
+	}
+
+	name, args = pingCommandForIP(netip.MustParseAddr("2001:db8::1"))
+	wantName := "ping6"
+	if runtime.GOOS == "linux" {
+		wantName = "ping"
+	}
+	if name != wantName || len(args) == 0 || args[len(args)-1] != "2001:db8::1" {
+		t.Errorf("pingCommandForIP(v6) = %q, %v, want %q ending in the address", name, args, wantName)
+	}
+}
+
+// stubProber returns the RTT at results[call] for each successive call, wrapping
+// around if there are more attempts than entries.
+type stubProber struct {
+	results []time.Duration // 0 means "unreachable" for that attempt
+	calls   int
+}
+
+func (p *stubProber) Probe(ctx context.Context, ip netip.Addr) (bool, time.Duration, error) {
+	rtt := p.results[p.calls%len(p.results)]
+	p.calls++
+	if rtt == 0 {
+		return false, 0, errStub
+	}
+	return true, rtt, nil
+}
+
+type stubError struct{}
+
+func (stubError) Error() string { return "stub probe failure" }
+
+var errStub = stubError{}
+
+func TestPoolRunKeepsMinimumRTTAcrossAttempts(t *testing.T) {
+	prober := &stubProber{results: []time.Duration{30 * time.Millisecond, 10 * time.Millisecond, 20 * time.Millisecond}}
+	pool := NewPool(prober, 1, 3)
+	pool.Backoff = 0
+
+	got := pool.Run(context.Background(), []string{"203.0.113.1"})
+	if len(got) != 1 {
+		t.Fatalf("Run() returned %d results, want 1: %+v", len(got), got)
+	}
+	if got[0].RTTms != 10 {
+		t.Errorf("RTTms = %v, want 10 (the minimum across all 3 attempts, not the first)", got[0].RTTms)
+	}
+	if got[0].Attempts != 3 {
+		t.Errorf("Attempts = %d, want 3 (every attempt runs)", got[0].Attempts)
+	}
+	if prober.calls != 3 {
+		t.Errorf("Probe was called %d times, want 3", prober.calls)
+	}
+}
+
+func TestPoolRunReachableIfAnyAttemptSucceeds(t *testing.T) {
+	prober := &stubProber{results: []time.Duration{0, 15 * time.Millisecond, 0}}
+	pool := NewPool(prober, 1, 3)
+	pool.Backoff = 0
+
+	got := pool.Run(context.Background(), []string{"203.0.113.1"})
+	if len(got) != 1 {
+		t.Fatalf("Run() returned %d results, want 1 (reachable on attempt 2): %+v", len(got), got)
+	}
+	if got[0].RTTms != 15 {
+		t.Errorf("RTTms = %v, want 15", got[0].RTTms)
+	}
+}
@@ -0,0 +1,73 @@
+package source
+
+import (
+	"context"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestParsePrefixLinesSkipsBlankAndInvalid(t *testing.T) {
+	input := "203.0.113.0/24\n\n  \nnot-a-cidr\n2001:db8::/32\n"
+
+	got, err := parsePrefixLines(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parsePrefixLines: %v", err)
+	}
+	want := []netip.Prefix{
+		netip.MustParsePrefix("203.0.113.0/24"),
+		netip.MustParsePrefix("2001:db8::/32"),
+	}
+	if len(got) != len(want) {
+		t.Fatalf("parsePrefixLines returned %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("parsePrefixLines()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+type stubSource struct {
+	prefixes []netip.Prefix
+	err      error
+}
+
+func (s stubSource) Fetch(ctx context.Context) ([]netip.Prefix, error) {
+	return s.prefixes, s.err
+}
+
+func TestMultiSourceUnionsPrefixes(t *testing.T) {
+	a := stubSource{prefixes: []netip.Prefix{netip.MustParsePrefix("203.0.113.0/24")}}
+	b := stubSource{prefixes: []netip.Prefix{netip.MustParsePrefix("198.51.100.0/24")}}
+
+	got, err := NewMultiSource(a, b).Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	want := append(append([]netip.Prefix{}, a.prefixes...), b.prefixes...)
+	if len(got) != len(want) {
+		t.Fatalf("Fetch() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Fetch()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestMultiSourceFailsFast(t *testing.T) {
+	errSource := stubSource{err: errTest}
+	ok := stubSource{prefixes: []netip.Prefix{netip.MustParsePrefix("203.0.113.0/24")}}
+
+	_, err := NewMultiSource(errSource, ok).Fetch(context.Background())
+	if err != errTest {
+		t.Fatalf("Fetch() error = %v, want %v", err, errTest)
+	}
+}
+
+var errTest = &testError{"boom"}
+
+type testError struct{ msg string }
+
+func (e *testError) Error() string { return e.msg }